@@ -0,0 +1,165 @@
+package flowdoc
+
+import (
+    "bytes"
+    "fmt"
+    "reflect"
+    "regexp"
+    "strings"
+)
+
+// ============================================
+// YAML-style Anchors and Aliases
+// ============================================
+
+var (
+    anchorTagRe = regexp.MustCompile(`^(\S+):\s*&(\S+)$`)
+    aliasRefRe  = regexp.MustCompile(`^(\S+)\s*[:=]\s*\*(\S+)$`)
+)
+
+// aliasRef is a placeholder left in the tree during parsing at a
+// `key = *anchor_name` / `key: *anchor_name` site; resolveAnchors replaces
+// it with the anchor's resolved value once the whole tree has been built.
+type aliasRef struct {
+    name string
+}
+
+// matchAnchorTag recognizes `key: &anchor_name`, which tags the subtree
+// that follows with a name other lines can later reference.
+func matchAnchorTag(trimmed string) (key, anchorName string, ok bool) {
+    m := anchorTagRe.FindStringSubmatch(trimmed)
+    if m == nil {
+        return "", "", false
+    }
+    return m[1], m[2], true
+}
+
+// matchAliasRef recognizes `key = *anchor_name` and `key: *anchor_name`,
+// both of which substitute a previously-tagged anchor's value.
+func matchAliasRef(trimmed string) (key, anchorName string, ok bool) {
+    m := aliasRefRe.FindStringSubmatch(trimmed)
+    if m == nil {
+        return "", "", false
+    }
+    return m[1], m[2], true
+}
+
+// resolveAnchors walks root replacing every aliasRef placeholder with the
+// value registered under anchors, detecting cycles along the way. Since
+// substituting an alias can make two anchor subtrees reference each other
+// (not just the root), cycle detection guards every map by pointer
+// identity, not just the alias names being dereferenced — otherwise a
+// cycle formed through two anchors (a references b, b references a) would
+// recurse forever instead of erroring. The reference that would close a
+// cycle is replaced with nil. It mutates root (and any anchor subtree
+// reachable from it) in place and returns the first error encountered, if
+// any.
+func resolveAnchors(root map[string]interface{}, anchors map[string]interface{}) (map[string]interface{}, error) {
+    visitingMap := make(map[uintptr]bool)
+    var err error
+
+    var resolve func(v interface{}) interface{}
+    resolve = func(v interface{}) interface{} {
+        switch vv := v.(type) {
+        case aliasRef:
+            target, ok := anchors[vv.name]
+            if !ok {
+                if err == nil {
+                    err = fmt.Errorf("flowdoc: undefined anchor reference %q", vv.name)
+                }
+                return nil
+            }
+            resolved := resolve(target)
+            anchors[vv.name] = resolved
+            return resolved
+        case map[string]interface{}:
+            ptr := mapPointer(vv)
+            if visitingMap[ptr] {
+                if err == nil {
+                    err = fmt.Errorf("flowdoc: anchor cycle detected in a shared subtree")
+                }
+                return nil
+            }
+            visitingMap[ptr] = true
+            for k, sub := range vv {
+                vv[k] = resolve(sub)
+            }
+            delete(visitingMap, ptr)
+            return vv
+        case []interface{}:
+            for i, sub := range vv {
+                vv[i] = resolve(sub)
+            }
+            return vv
+        default:
+            return v
+        }
+    }
+
+    resolved := resolve(root)
+    return resolved.(map[string]interface{}), err
+}
+
+// StringifyFlowWithAnchors behaves like StringifyFlow but re-emits a
+// subtree as `key: &anchorN` / `key: *anchorN` the first/subsequent times
+// the same map[string]interface{} (by pointer identity) is encountered,
+// so round-tripping a ParseFlow result preserves sharing instead of
+// duplicating it on disk.
+func StringifyFlowWithAnchors(obj map[string]interface{}) string {
+    seen := make(map[uintptr]int) // pointer -> occurrence count
+    countRefs(obj, seen)
+
+    named := make(map[uintptr]string) // pointer -> assigned anchor name
+    nextAnchor := 1
+
+    var b bytes.Buffer
+    var writeObj func(map[string]interface{}, int)
+    writeObj = func(o map[string]interface{}, indent int) {
+        pad := strings.Repeat(" ", indent)
+        for k, v := range o {
+            switch vv := v.(type) {
+            case map[string]interface{}:
+                ptr := mapPointer(vv)
+                if name, ok := named[ptr]; ok {
+                    b.WriteString(pad + k + ": *" + name + "\n")
+                    continue
+                }
+                if seen[ptr] > 1 {
+                    name := fmt.Sprintf("anchor%d", nextAnchor)
+                    nextAnchor++
+                    named[ptr] = name
+                    b.WriteString(pad + k + ": &" + name + "\n")
+                } else {
+                    b.WriteString(pad + k + ":\n")
+                }
+                writeObj(vv, indent+2)
+            case []interface{}:
+                arr := make([]string, 0, len(vv))
+                for _, e := range vv {
+                    arr = append(arr, stringifyBasic(e))
+                }
+                b.WriteString(pad + k + " = [" + strings.Join(arr, ", ") + "]\n")
+            default:
+                b.WriteString(pad + k + " = " + stringifyBasic(v) + "\n")
+            }
+        }
+    }
+    writeObj(obj, 0)
+    return b.String()
+}
+
+func countRefs(o map[string]interface{}, seen map[uintptr]int) {
+    for _, v := range o {
+        if sub, ok := v.(map[string]interface{}); ok {
+            ptr := mapPointer(sub)
+            seen[ptr]++
+            if seen[ptr] == 1 {
+                countRefs(sub, seen)
+            }
+        }
+    }
+}
+
+func mapPointer(m map[string]interface{}) uintptr {
+    return reflect.ValueOf(m).Pointer()
+}
@@ -0,0 +1,115 @@
+package flowdoc
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestParseFlowTopLevelNesting(t *testing.T) {
+    root := ParseFlow("base:\n  x = 1\n  y = 2\n")
+    base, ok := root["base"].(map[string]interface{})
+    if !ok {
+        t.Fatalf("expected top-level key %q to hold a map, got %v", "base", root["base"])
+    }
+    if base["x"] != 1 || base["y"] != 2 {
+        t.Errorf("expected base = {x:1, y:2}, got %v", base)
+    }
+}
+
+func TestParseFlowStrictAnchorAndAlias(t *testing.T) {
+    root, err := ParseFlowStrict("base: &b\n  x = 1\nderived = *b\n")
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    base, ok := root["base"].(map[string]interface{})
+    if !ok {
+        t.Fatalf("expected %q to hold a map, got %v", "base", root["base"])
+    }
+    derived, ok := root["derived"].(map[string]interface{})
+    if !ok {
+        t.Fatalf("expected %q to hold a map, got %v", "derived", root["derived"])
+    }
+    if base["x"] != 1 || derived["x"] != 1 {
+        t.Errorf("expected both base and derived to carry x=1, got base=%v derived=%v", base, derived)
+    }
+}
+
+func TestParseFlowStrictUndefinedAnchor(t *testing.T) {
+    if _, err := ParseFlowStrict("derived = *missing\n"); err == nil {
+        t.Fatal("expected an error for an undefined anchor reference")
+    }
+}
+
+func TestParseFlowStrictAnchorCycle(t *testing.T) {
+    text := "a: &a\n  ref = *b\nb: &b\n  ref = *a\n"
+    if _, err := ParseFlowStrict(text); err == nil {
+        t.Fatal("expected an error for an anchor cycle")
+    }
+}
+
+func TestParseFlowWithModelTopLevelModels(t *testing.T) {
+    text := "$models:\n" +
+        "  User:\n" +
+        "    fields:\n" +
+        "      name:\n" +
+        "        type = \"string\"\n" +
+        "use_model = \"User\"\n" +
+        "profile:\n" +
+        "  name = \"Ada\"\n"
+
+    result, err := ParseFlowWithModel(text, nil)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    profile, ok := result["profile"].(map[string]interface{})
+    if !ok {
+        t.Fatalf("expected %q to hold a map, got %v", "profile", result["profile"])
+    }
+    if profile["name"] != "Ada" {
+        t.Errorf("expected profile.name = Ada, got %v", profile["name"])
+    }
+}
+
+func TestStringifyFlowWithAnchorsEmitsAnchorAndAlias(t *testing.T) {
+    shared := map[string]interface{}{"x": 1}
+    root := map[string]interface{}{
+        "base":    shared,
+        "derived": shared,
+    }
+
+    out := StringifyFlowWithAnchors(root)
+    if !strings.Contains(out, "&anchor1") {
+        t.Fatalf("expected the first occurrence of a shared subtree to be tagged with an anchor, got:\n%s", out)
+    }
+    if !strings.Contains(out, "*anchor1") {
+        t.Fatalf("expected the second occurrence of a shared subtree to be an alias reference, got:\n%s", out)
+    }
+
+    reparsed, err := ParseFlowStrict(out)
+    if err != nil {
+        t.Fatalf("unexpected error re-parsing stringified anchors: %v", err)
+    }
+    base, ok := reparsed["base"].(map[string]interface{})
+    if !ok {
+        t.Fatalf("expected %q to hold a map, got %v", "base", reparsed["base"])
+    }
+    derived, ok := reparsed["derived"].(map[string]interface{})
+    if !ok {
+        t.Fatalf("expected %q to hold a map, got %v", "derived", reparsed["derived"])
+    }
+    if base["x"] != 1 || derived["x"] != 1 {
+        t.Errorf("expected both base and derived to carry x=1 after round-tripping, got base=%v derived=%v", base, derived)
+    }
+}
+
+func TestStringifyFlowWithAnchorsLeavesUnsharedSubtreesPlain(t *testing.T) {
+    root := map[string]interface{}{
+        "base": map[string]interface{}{"x": 1},
+    }
+
+    out := StringifyFlowWithAnchors(root)
+    if strings.Contains(out, "&") || strings.Contains(out, "*") {
+        t.Errorf("did not expect an anchor or alias for a subtree referenced only once, got:\n%s", out)
+    }
+}
@@ -0,0 +1,317 @@
+package flowdoc
+
+import (
+    "bytes"
+    "encoding/binary"
+    "encoding/json"
+    "fmt"
+    "math"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+
+    cbor "github.com/fxamacker/cbor/v2"
+    msgpack "github.com/vmihailenco/msgpack/v5"
+)
+
+// ============================================
+// Pluggable Codec Registry
+// ============================================
+
+// Codec is anything that can turn a FlowDoc tree into bytes and back,
+// identified by the file extension it owns (without the leading dot).
+type Codec interface {
+    Marshal(map[string]interface{}) ([]byte, error)
+    Unmarshal([]byte, *map[string]interface{}) error
+    Extension() string
+}
+
+var codecRegistry = make(map[string]Codec)
+
+// RegisterCodec makes c available under name, for LoadBinary/SaveBinary to
+// look up either by that name or by c.Extension().
+func RegisterCodec(name string, c Codec) {
+    codecRegistry[name] = c
+}
+
+func init() {
+    RegisterCodec("msgpack", msgpackCodec{})
+    RegisterCodec("cbor", cborCodec{})
+    RegisterCodec("json", jsonCodec{})
+}
+
+func codecByExtension(ext string) (Codec, bool) {
+    ext = strings.TrimPrefix(ext, ".")
+    for _, c := range codecRegistry {
+        if c.Extension() == ext {
+            return c, true
+        }
+    }
+    return nil, false
+}
+
+// LoadBinary reads path and unmarshals it using the codec registered for
+// its file extension (e.g. ".flowb" -> msgpack, ".cbor" -> cbor).
+func LoadBinary(path string) (map[string]interface{}, error) {
+    codec, ok := codecByExtension(filepath.Ext(path))
+    if !ok {
+        return nil, fmt.Errorf("flowdoc: no codec registered for extension %q", filepath.Ext(path))
+    }
+    data, err := os.ReadFile(path)
+    if err != nil {
+        return nil, err
+    }
+    var out map[string]interface{}
+    err = codec.Unmarshal(data, &out)
+    return out, err
+}
+
+// SaveBinary marshals obj with the codec registered under codecName (a
+// name passed to RegisterCodec, not necessarily the file extension) and
+// writes the result to path.
+func SaveBinary(path string, obj map[string]interface{}, codecName string) error {
+    codec, ok := codecRegistry[codecName]
+    if !ok {
+        return fmt.Errorf("flowdoc: no codec registered under name %q", codecName)
+    }
+    data, err := codec.Marshal(obj)
+    if err != nil {
+        return err
+    }
+    return os.WriteFile(path, data, 0644)
+}
+
+// ---- built-in codecs ----
+
+type msgpackCodec struct{}
+
+func (msgpackCodec) Marshal(v map[string]interface{}) ([]byte, error) { return msgpack.Marshal(v) }
+func (msgpackCodec) Unmarshal(data []byte, out *map[string]interface{}) error {
+    return msgpack.Unmarshal(data, out)
+}
+func (msgpackCodec) Extension() string { return "flowb" }
+
+type cborCodec struct{}
+
+func (cborCodec) Marshal(v map[string]interface{}) ([]byte, error) { return cbor.Marshal(v) }
+func (cborCodec) Unmarshal(data []byte, out *map[string]interface{}) error {
+    return cbor.Unmarshal(data, out)
+}
+func (cborCodec) Extension() string { return "cbor" }
+
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v map[string]interface{}) ([]byte, error) { return json.Marshal(v) }
+func (jsonCodec) Unmarshal(data []byte, out *map[string]interface{}) error {
+    return json.Unmarshal(data, out)
+}
+func (jsonCodec) Extension() string { return "json" }
+
+// ============================================
+// Schema-full Protobuf Codec
+// ============================================
+
+// protoScalarType maps a FlowDoc FieldType to the proto3 scalar type used
+// in both ToProtoDescriptor and the wire codec below.
+func protoScalarType(fieldType string) string {
+    switch fieldType {
+    case "int":
+        return "int64"
+    case "float":
+        return "double"
+    case "bool":
+        return "bool"
+    default: // "string", "date", "datetime", unknown
+        return "string"
+    }
+}
+
+// ToProtoDescriptor renders model as a proto3 message definition, using
+// FieldID as the wire field number so it round-trips with ModelRegistry.ProtobufCodec.
+func (model *ModelDefinition) ToProtoDescriptor() string {
+    fields := make([]*FieldDefinition, 0, len(model.Fields))
+    for _, f := range model.Fields {
+        fields = append(fields, f)
+    }
+    sort.Slice(fields, func(i, j int) bool {
+        return fieldIDOrMax(fields[i]) < fieldIDOrMax(fields[j])
+    })
+
+    var b strings.Builder
+    fmt.Fprintf(&b, "message %s {\n", model.Name)
+    for _, f := range fields {
+        if f.FieldID == nil {
+            continue
+        }
+        fmt.Fprintf(&b, "  %s %s = %d;\n", protoScalarType(f.FieldType), f.Alias, *f.FieldID)
+    }
+    b.WriteString("}\n")
+    return b.String()
+}
+
+func fieldIDOrMax(f *FieldDefinition) int {
+    if f.FieldID == nil {
+        return math.MaxInt32
+    }
+    return *f.FieldID
+}
+
+// protobufCodec is a Codec driven entirely by a ModelDefinition: FieldID
+// becomes the protobuf field number, FieldType its wire type. It hand-rolls
+// the protobuf wire format (varint/fixed64/length-delimited) rather than
+// depending on a generated .pb.go, since the schema is only known at
+// runtime.
+type protobufCodec struct {
+    model *ModelDefinition
+}
+
+// ProtobufCodec returns a Codec for the named model, so a registered
+// ModelRegistry can drive a schema-full protobuf encoding of documents
+// that were parsed with use_model set to that model.
+func (r *ModelRegistry) ProtobufCodec(modelName string) (Codec, error) {
+    model := r.GetModel(modelName)
+    if model == nil {
+        return nil, fmt.Errorf("flowdoc: model %q not found in registry", modelName)
+    }
+    return &protobufCodec{model: model}, nil
+}
+
+func (c *protobufCodec) Extension() string { return "pb" }
+
+const (
+    protoWireVarint  = 0
+    protoWireFixed64 = 1
+    protoWireBytes   = 2
+)
+
+func (c *protobufCodec) Marshal(data map[string]interface{}) ([]byte, error) {
+    var buf bytes.Buffer
+    for _, field := range c.model.Fields {
+        if field.FieldID == nil {
+            continue
+        }
+        value, ok := data[field.FullName]
+        if !ok {
+            continue
+        }
+
+        switch field.FieldType {
+        case "int":
+            f, _ := toFloat64(value)
+            writeProtoTag(&buf, *field.FieldID, protoWireVarint)
+            writeProtoVarint(&buf, uint64(int64(f)))
+        case "bool":
+            b, _ := value.(bool)
+            writeProtoTag(&buf, *field.FieldID, protoWireVarint)
+            if b {
+                writeProtoVarint(&buf, 1)
+            } else {
+                writeProtoVarint(&buf, 0)
+            }
+        case "float":
+            f, _ := toFloat64(value)
+            writeProtoTag(&buf, *field.FieldID, protoWireFixed64)
+            var bits [8]byte
+            binary.LittleEndian.PutUint64(bits[:], math.Float64bits(f))
+            buf.Write(bits[:])
+        default: // "string", "date", "datetime"
+            s := fmt.Sprintf("%v", value)
+            writeProtoTag(&buf, *field.FieldID, protoWireBytes)
+            writeProtoVarint(&buf, uint64(len(s)))
+            buf.WriteString(s)
+        }
+    }
+    return buf.Bytes(), nil
+}
+
+func (c *protobufCodec) Unmarshal(data []byte, out *map[string]interface{}) error {
+    fieldByID := make(map[int]*FieldDefinition, len(c.model.Fields))
+    for _, f := range c.model.Fields {
+        if f.FieldID != nil {
+            fieldByID[*f.FieldID] = f
+        }
+    }
+
+    result := make(map[string]interface{})
+    pos := 0
+    for pos < len(data) {
+        tag, n, err := readProtoVarint(data[pos:])
+        if err != nil {
+            return err
+        }
+        pos += n
+        fieldID := int(tag >> 3)
+        wireType := int(tag & 0x7)
+        field := fieldByID[fieldID]
+
+        switch wireType {
+        case protoWireVarint:
+            val, n, err := readProtoVarint(data[pos:])
+            if err != nil {
+                return err
+            }
+            pos += n
+            if field != nil {
+                if field.FieldType == "bool" {
+                    result[field.FullName] = val != 0
+                } else {
+                    result[field.FullName] = int(int64(val))
+                }
+            }
+        case protoWireFixed64:
+            if pos+8 > len(data) {
+                return fmt.Errorf("flowdoc: truncated fixed64 field")
+            }
+            bits := binary.LittleEndian.Uint64(data[pos : pos+8])
+            pos += 8
+            if field != nil {
+                result[field.FullName] = math.Float64frombits(bits)
+            }
+        case protoWireBytes:
+            length, n, err := readProtoVarint(data[pos:])
+            if err != nil {
+                return err
+            }
+            pos += n
+            if pos+int(length) > len(data) {
+                return fmt.Errorf("flowdoc: truncated length-delimited field")
+            }
+            s := string(data[pos : pos+int(length)])
+            pos += int(length)
+            if field != nil {
+                result[field.FullName] = s
+            }
+        default:
+            return fmt.Errorf("flowdoc: unsupported protobuf wire type %d", wireType)
+        }
+    }
+
+    *out = result
+    return nil
+}
+
+func writeProtoTag(buf *bytes.Buffer, fieldID int, wireType int) {
+    writeProtoVarint(buf, uint64(fieldID)<<3|uint64(wireType))
+}
+
+func writeProtoVarint(buf *bytes.Buffer, v uint64) {
+    for v >= 0x80 {
+        buf.WriteByte(byte(v) | 0x80)
+        v >>= 7
+    }
+    buf.WriteByte(byte(v))
+}
+
+func readProtoVarint(data []byte) (uint64, int, error) {
+    var result uint64
+    var shift uint
+    for i, b := range data {
+        result |= uint64(b&0x7f) << shift
+        if b < 0x80 {
+            return result, i + 1, nil
+        }
+        shift += 7
+    }
+    return 0, 0, fmt.Errorf("flowdoc: truncated varint")
+}
@@ -0,0 +1,126 @@
+package flowdoc
+
+import (
+    "math"
+    "testing"
+)
+
+func newTestUserModel() *ModelDefinition {
+    intID, boolID, floatID, strID := 1, 2, 3, 4
+    model := NewModelDefinition("User")
+    model.AddField(&FieldDefinition{FullName: "age", Alias: "age", FieldType: "int", FieldID: &intID})
+    model.AddField(&FieldDefinition{FullName: "active", Alias: "active", FieldType: "bool", FieldID: &boolID})
+    model.AddField(&FieldDefinition{FullName: "score", Alias: "score", FieldType: "float", FieldID: &floatID})
+    model.AddField(&FieldDefinition{FullName: "name", Alias: "name", FieldType: "string", FieldID: &strID})
+    return model
+}
+
+func TestProtobufCodecRoundTrip(t *testing.T) {
+    registry := NewModelRegistry()
+    model := newTestUserModel()
+    registry.RegisterModel(model)
+
+    codec, err := registry.ProtobufCodec("User")
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    in := map[string]interface{}{
+        "age":    30,
+        "active": true,
+        "score":  9.5,
+        "name":   "Ada",
+    }
+    data, err := codec.Marshal(in)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    var out map[string]interface{}
+    if err := codec.Unmarshal(data, &out); err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    if out["age"] != 30 {
+        t.Errorf("expected age = 30, got %v", out["age"])
+    }
+    if out["active"] != true {
+        t.Errorf("expected active = true, got %v", out["active"])
+    }
+    if f, ok := out["score"].(float64); !ok || math.Abs(f-9.5) > 1e-9 {
+        t.Errorf("expected score = 9.5, got %v", out["score"])
+    }
+    if out["name"] != "Ada" {
+        t.Errorf("expected name = Ada, got %v", out["name"])
+    }
+}
+
+func TestProtobufCodecSkipsFieldsWithoutID(t *testing.T) {
+    model := NewModelDefinition("User")
+    model.AddField(&FieldDefinition{FullName: "untagged", Alias: "untagged", FieldType: "string"})
+
+    codec, err := (&ModelRegistry{models: map[string]*ModelDefinition{"User": model}}).ProtobufCodec("User")
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    data, err := codec.Marshal(map[string]interface{}{"untagged": "value"})
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if len(data) != 0 {
+        t.Errorf("expected no bytes for a field with no FieldID, got %d bytes", len(data))
+    }
+}
+
+func TestProtobufCodecMissingModel(t *testing.T) {
+    registry := NewModelRegistry()
+    if _, err := registry.ProtobufCodec("Missing"); err == nil {
+        t.Fatal("expected an error for an unregistered model")
+    }
+}
+
+func TestProtobufCodecUnmarshalUnknownFieldID(t *testing.T) {
+    registry := NewModelRegistry()
+    model := newTestUserModel()
+    registry.RegisterModel(model)
+    codec, err := registry.ProtobufCodec("User")
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    data, err := codec.Marshal(map[string]interface{}{"age": 30, "name": "Ada"})
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    // A model that no longer knows about field 1 ("age") should skip it
+    // rather than error, since wire-compatible schema evolution depends on
+    // unrecognized fields being ignorable.
+    trimmed := NewModelDefinition("User")
+    strID := 4
+    trimmed.AddField(&FieldDefinition{FullName: "name", Alias: "name", FieldType: "string", FieldID: &strID})
+    trimmedRegistry := &ModelRegistry{models: map[string]*ModelDefinition{"User": trimmed}}
+    trimmedCodec, err := trimmedRegistry.ProtobufCodec("User")
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    var out map[string]interface{}
+    if err := trimmedCodec.Unmarshal(data, &out); err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if _, ok := out["age"]; ok {
+        t.Errorf("expected the unrecognized field to be dropped, got %v", out["age"])
+    }
+    if out["name"] != "Ada" {
+        t.Errorf("expected name = Ada, got %v", out["name"])
+    }
+}
+
+func TestProtobufCodecExtension(t *testing.T) {
+    codec := &protobufCodec{model: NewModelDefinition("User")}
+    if ext := codec.Extension(); ext != "pb" {
+        t.Errorf("expected extension %q, got %q", "pb", ext)
+    }
+}
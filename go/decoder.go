@@ -0,0 +1,290 @@
+package flowdoc
+
+import (
+    "bufio"
+    "encoding/json"
+    "io"
+    "strings"
+
+    msgpack "github.com/vmihailenco/msgpack/v5"
+)
+
+// ============================================
+// Streaming Decoder / Encoder
+// ============================================
+
+// EventType identifies the kind of token a Decoder's Next returns.
+type EventType int
+
+const (
+    MapStart EventType = iota
+    MapEnd
+    ArrayStart
+    ArrayEnd
+    Scalar
+)
+
+// Event is one token of a FlowDoc stream. Key is set for MapStart and
+// Scalar/ArrayStart events that are a map entry (empty for array
+// elements and *End events). Value is set only for Scalar, and holds an
+// aliasRef placeholder (see anchors.go) for an unresolved `key = *name`
+// reference. Anchor is set on a MapStart produced by `key: &name` and
+// names the anchor the map was tagged with, empty otherwise.
+type Event struct {
+    Type   EventType
+    Key    string
+    Value  interface{}
+    Anchor string
+}
+
+// Decoder reads a FlowDoc document one token at a time off an io.Reader,
+// so callers can process multi-megabyte documents without materializing
+// the whole tree in memory. It follows the same indentation grammar as
+// ParseFlow -- which is itself built on Decoder, see decodeTreeWithAnchors
+// -- including anchor tags and alias references, but leaves aliases
+// unresolved; resolveAnchors is what turns an aliasRef Value into the
+// anchor's actual value.
+type Decoder struct {
+    sc    *bufio.Scanner
+    queue []Event
+    stack []int // child-indent levels; stack[0] is the root sentinel
+    eof   bool
+}
+
+// NewDecoder returns a Decoder reading from r.
+func NewDecoder(r io.Reader) *Decoder {
+    return &Decoder{sc: bufio.NewScanner(r), stack: []int{0}}
+}
+
+// Next returns the next token, or io.EOF once the stream (including the
+// implicit MapEnd for every map still open) is exhausted.
+func (d *Decoder) Next() (Event, error) {
+    for len(d.queue) == 0 {
+        if !d.fill() {
+            return Event{}, io.EOF
+        }
+    }
+    ev := d.queue[0]
+    d.queue = d.queue[1:]
+    return ev, nil
+}
+
+// fill reads lines until at least one event is queued, or drains the
+// remaining open maps once the underlying reader is exhausted. It
+// returns false only when there is nothing left to produce.
+func (d *Decoder) fill() bool {
+    if d.eof {
+        if len(d.stack) > 1 {
+            d.stack = d.stack[:len(d.stack)-1]
+            d.queue = append(d.queue, Event{Type: MapEnd})
+            return true
+        }
+        return false
+    }
+
+    for d.sc.Scan() {
+        no, _, blank := splitComment(d.sc.Text())
+        if blank {
+            continue
+        }
+        indent := indentOf(no)
+        trimmed := strings.TrimSpace(no)
+
+        if key, anchorName, ok := matchAnchorTag(trimmed); ok {
+            for len(d.stack) > 1 && d.stack[len(d.stack)-1] > indent {
+                d.stack = d.stack[:len(d.stack)-1]
+                d.queue = append(d.queue, Event{Type: MapEnd})
+            }
+            d.queue = append(d.queue, Event{Type: MapStart, Key: key, Anchor: anchorName})
+            d.stack = append(d.stack, indent+1)
+            return true
+        }
+
+        if key, anchorName, ok := matchAliasRef(trimmed); ok {
+            for len(d.stack) > 1 && d.stack[len(d.stack)-1] > indent {
+                d.stack = d.stack[:len(d.stack)-1]
+                d.queue = append(d.queue, Event{Type: MapEnd})
+            }
+            d.queue = append(d.queue, Event{Type: Scalar, Key: key, Value: aliasRef{anchorName}})
+            return true
+        }
+
+        if strings.HasSuffix(trimmed, ":") {
+            key := strings.TrimSpace(trimmed[:len(trimmed)-1])
+            for len(d.stack) > 1 && d.stack[len(d.stack)-1] > indent {
+                d.stack = d.stack[:len(d.stack)-1]
+                d.queue = append(d.queue, Event{Type: MapEnd})
+            }
+            d.queue = append(d.queue, Event{Type: MapStart, Key: key})
+            d.stack = append(d.stack, indent+1)
+            return true
+        }
+
+        if !strings.Contains(trimmed, "=") {
+            continue
+        }
+        kv := strings.SplitN(trimmed, "=", 2)
+        key := strings.TrimSpace(kv[0])
+        val := parseValue(strings.TrimSpace(kv[1]))
+        for len(d.stack) > 1 && d.stack[len(d.stack)-1] > indent {
+            d.stack = d.stack[:len(d.stack)-1]
+            d.queue = append(d.queue, Event{Type: MapEnd})
+        }
+        if arr, ok := val.([]interface{}); ok {
+            d.queue = append(d.queue, Event{Type: ArrayStart, Key: key})
+            for _, e := range arr {
+                d.queue = append(d.queue, Event{Type: Scalar, Value: e})
+            }
+            d.queue = append(d.queue, Event{Type: ArrayEnd})
+        } else {
+            d.queue = append(d.queue, Event{Type: Scalar, Key: key, Value: val})
+        }
+        return true
+    }
+    d.eof = true
+    return d.fill()
+}
+
+// Decode consumes the rest of the stream and unmarshals it into v. When v
+// is a *map[string]interface{}, the assembled tree is assigned directly;
+// for any other target it is round-tripped through encoding/json, the
+// same way json.Unmarshal would decode it. Either way Decode must
+// assemble the full tree in memory first -- callers that want the
+// memory-saving benefit of streaming a multi-megabyte document should
+// drive Next() themselves instead.
+func (d *Decoder) Decode(v interface{}) error {
+    tree, _, err := d.decodeTreeWithAnchors()
+    if err != nil {
+        return err
+    }
+    if out, ok := v.(*map[string]interface{}); ok {
+        *out = tree
+        return nil
+    }
+    b, err := json.Marshal(tree)
+    if err != nil {
+        return err
+    }
+    return json.Unmarshal(b, v)
+}
+
+func (d *Decoder) decodeTree() (map[string]interface{}, error) {
+    tree, _, err := d.decodeTreeWithAnchors()
+    return tree, err
+}
+
+// decodeTreeWithAnchors drains the stream into a plain map, the same way
+// decodeTree does, but also collects a name -> subtree map for every
+// MapStart event carrying an Anchor, the way parseFlowTree needs in order
+// to resolve `key = *name` aliases afterward (see anchors.go). This is
+// the shared assembly step ParseFlow and Decoder.Decode are both built
+// on top of.
+func (d *Decoder) decodeTreeWithAnchors() (map[string]interface{}, map[string]interface{}, error) {
+    root := make(map[string]interface{})
+    anchors := make(map[string]interface{})
+    type frame struct {
+        node map[string]interface{}
+    }
+    stack := []frame{{root}}
+
+    for {
+        ev, err := d.Next()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, nil, err
+        }
+
+        switch ev.Type {
+        case MapStart:
+            child := make(map[string]interface{})
+            stack[len(stack)-1].node[ev.Key] = child
+            if ev.Anchor != "" {
+                anchors[ev.Anchor] = child
+            }
+            stack = append(stack, frame{child})
+        case MapEnd:
+            if len(stack) > 1 {
+                stack = stack[:len(stack)-1]
+            }
+        case ArrayStart:
+            var arr []interface{}
+            for {
+                sub, err := d.Next()
+                if err != nil || sub.Type == ArrayEnd {
+                    break
+                }
+                arr = append(arr, sub.Value)
+            }
+            stack[len(stack)-1].node[ev.Key] = arr
+        case Scalar:
+            stack[len(stack)-1].node[ev.Key] = ev.Value
+        }
+    }
+
+    return root, anchors, nil
+}
+
+// Encoder writes a FlowDoc document to an io.Writer. It mirrors Decoder:
+// where Decoder turns a stream into a value, Encoder turns a value into a
+// stream.
+type Encoder struct {
+    w io.Writer
+}
+
+// NewEncoder returns an Encoder writing to w.
+func NewEncoder(w io.Writer) *Encoder {
+    return &Encoder{w: w}
+}
+
+// Encode marshals v to JSON and back into a map[string]interface{} (so any
+// JSON-compatible value works, not just map[string]interface{}), then
+// writes it as FlowDoc text straight to e.w via writeFlowObj -- the same
+// primitive StringifyFlow builds on, so there's one implementation of the
+// format's output side, not two.
+func (e *Encoder) Encode(v interface{}) error {
+    b, err := json.Marshal(v)
+    if err != nil {
+        return err
+    }
+    var obj map[string]interface{}
+    if err := json.Unmarshal(b, &obj); err != nil {
+        return err
+    }
+    writeFlowObj(e.w, obj, 0)
+    return nil
+}
+
+// FlowbStreamDecoder scans a .flowb (msgpack) stream record-by-record
+// instead of loading the whole file into memory, for callers that stored
+// a sequence of values rather than one big document.
+type FlowbStreamDecoder struct {
+    dec *msgpack.Decoder
+}
+
+// NewFlowbStreamDecoder returns a FlowbStreamDecoder reading from r.
+func NewFlowbStreamDecoder(r io.Reader) *FlowbStreamDecoder {
+    return &FlowbStreamDecoder{dec: msgpack.NewDecoder(r)}
+}
+
+// Decode reads the next msgpack-encoded record into v.
+func (d *FlowbStreamDecoder) Decode(v interface{}) error {
+    return d.dec.Decode(v)
+}
+
+// FlowbStreamEncoder writes .flowb (msgpack) records to an io.Writer one
+// at a time, mirroring FlowbStreamDecoder.
+type FlowbStreamEncoder struct {
+    enc *msgpack.Encoder
+}
+
+// NewFlowbStreamEncoder returns a FlowbStreamEncoder writing to w.
+func NewFlowbStreamEncoder(w io.Writer) *FlowbStreamEncoder {
+    return &FlowbStreamEncoder{enc: msgpack.NewEncoder(w)}
+}
+
+// Encode writes v as the next msgpack-encoded record.
+func (e *FlowbStreamEncoder) Encode(v interface{}) error {
+    return e.enc.Encode(v)
+}
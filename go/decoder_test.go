@@ -0,0 +1,67 @@
+package flowdoc
+
+import (
+    "io"
+    "strings"
+    "testing"
+)
+
+func TestDecoderDirectlyNestedObjects(t *testing.T) {
+    dec := NewDecoder(strings.NewReader("a:\n  b:\n    c = 1\n"))
+
+    var got []Event
+    for {
+        ev, err := dec.Next()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            t.Fatalf("unexpected error: %v", err)
+        }
+        got = append(got, ev)
+    }
+
+    want := []EventType{MapStart, MapStart, Scalar, MapEnd, MapEnd}
+    if len(got) != len(want) {
+        t.Fatalf("expected %d events, got %d: %+v", len(want), len(got), got)
+    }
+    for i, ev := range got {
+        if ev.Type != want[i] {
+            t.Errorf("event %d: expected type %v, got %v (%+v)", i, want[i], ev.Type, ev)
+        }
+    }
+    if got[0].Key != "a" || got[1].Key != "b" || got[2].Key != "c" {
+        t.Errorf("expected keys a, b, c in order, got %q, %q, %q", got[0].Key, got[1].Key, got[2].Key)
+    }
+    if got[2].Value != 1 {
+        t.Errorf("expected c = 1, got %v", got[2].Value)
+    }
+}
+
+func TestDecoderDecodeIntoMap(t *testing.T) {
+    dec := NewDecoder(strings.NewReader("base:\n  x = 1\n  y = 2\n"))
+
+    var out map[string]interface{}
+    if err := dec.Decode(&out); err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    base, ok := out["base"].(map[string]interface{})
+    if !ok {
+        t.Fatalf("expected %q to hold a map, got %v", "base", out["base"])
+    }
+    if base["x"] != 1 || base["y"] != 2 {
+        t.Errorf("expected base = {x:1, y:2}, got %v", base)
+    }
+}
+
+func TestDecoderAnchorTagCarriesThroughEvents(t *testing.T) {
+    dec := NewDecoder(strings.NewReader("base: &b\n  x = 1\n"))
+
+    ev, err := dec.Next()
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    if ev.Type != MapStart || ev.Anchor != "b" {
+        t.Errorf("expected a MapStart tagged with anchor %q, got %+v", "b", ev)
+    }
+}
@@ -0,0 +1,173 @@
+package flowdoc
+
+import (
+    "bytes"
+    "strings"
+)
+
+// ============================================
+// Order- and Comment-Preserving Document Model
+// ============================================
+
+// DocNode is one key/value pair of a Document. Value is either a scalar,
+// a []interface{}, or a nested *Document. LeadingComments holds whole-line
+// "# ..." comments that appeared directly above the key in source;
+// TrailingComment holds an inline "key = value # ..." comment.
+type DocNode struct {
+    Key             string
+    Value           interface{}
+    LeadingComments []string
+    TrailingComment string
+}
+
+// Document is an ordered map: unlike map[string]interface{}, iterating it
+// (via Nodes) always yields keys in the order they were added, and each
+// node can carry its own comments. It is FlowDoc's editable counterpart to
+// the plain-map API used by ParseFlow/StringifyFlow.
+type Document struct {
+    nodes []*DocNode
+    index map[string]int
+}
+
+// NewDocument returns an empty Document.
+func NewDocument() *Document {
+    return &Document{index: make(map[string]int)}
+}
+
+// Nodes returns the document's entries in insertion order.
+func (d *Document) Nodes() []*DocNode {
+    return d.nodes
+}
+
+// Get returns the node for key, or nil if it isn't present.
+func (d *Document) Get(key string) *DocNode {
+    if i, ok := d.index[key]; ok {
+        return d.nodes[i]
+    }
+    return nil
+}
+
+// Set adds key/value, or replaces the value of an existing key in place
+// (preserving its position and comments).
+func (d *Document) Set(key string, value interface{}) *DocNode {
+    if i, ok := d.index[key]; ok {
+        d.nodes[i].Value = value
+        return d.nodes[i]
+    }
+    node := &DocNode{Key: key, Value: value}
+    d.index[key] = len(d.nodes)
+    d.nodes = append(d.nodes, node)
+    return node
+}
+
+type docParseFrame struct {
+    indent int
+    doc    *Document
+}
+
+// ParseFlowDocument parses FlowDoc text into an order- and
+// comment-preserving Document.
+func ParseFlowDocument(text string) *Document {
+    lines := tokenizeLines(text)
+    root := NewDocument()
+    stack := []docParseFrame{{0, root}}
+
+    for _, tl := range lines {
+        indent := indentOf(tl.Text)
+        trimmed := strings.TrimSpace(tl.Text)
+
+        if strings.HasSuffix(trimmed, ":") {
+            key := strings.TrimSpace(trimmed[:len(trimmed)-1])
+            for len(stack) > 1 && stack[len(stack)-1].indent > indent {
+                stack = stack[:len(stack)-1]
+            }
+            child := NewDocument()
+            node := stack[len(stack)-1].doc.Set(key, child)
+            node.LeadingComments = tl.Leading
+            node.TrailingComment = tl.Comment
+            stack = append(stack, docParseFrame{indent + 1, child})
+            continue
+        }
+
+        if !strings.Contains(trimmed, "=") {
+            continue
+        }
+        parts := strings.SplitN(trimmed, "=", 2)
+        key := strings.TrimSpace(parts[0])
+        raw := strings.TrimSpace(parts[1])
+        for len(stack) > 1 && stack[len(stack)-1].indent > indent {
+            stack = stack[:len(stack)-1]
+        }
+        node := stack[len(stack)-1].doc.Set(key, parseValue(raw))
+        node.LeadingComments = tl.Leading
+        node.TrailingComment = tl.Comment
+    }
+
+    return root
+}
+
+// Stringify renders the Document back to FlowDoc text, re-emitting every
+// leading and trailing comment alongside its key.
+func (d *Document) Stringify() string {
+    var b bytes.Buffer
+    var write func(*Document, int)
+    write = func(doc *Document, indent int) {
+        pad := strings.Repeat(" ", indent)
+        for _, node := range doc.Nodes() {
+            for _, c := range node.LeadingComments {
+                b.WriteString(pad + "# " + c + "\n")
+            }
+            switch v := node.Value.(type) {
+            case *Document:
+                b.WriteString(pad + node.Key + ":" + trailingCommentSuffix(node.TrailingComment) + "\n")
+                write(v, indent+2)
+            case []interface{}:
+                arr := make([]string, 0, len(v))
+                for _, e := range v {
+                    arr = append(arr, stringifyBasic(e))
+                }
+                b.WriteString(pad + node.Key + " = [" + strings.Join(arr, ", ") + "]" + trailingCommentSuffix(node.TrailingComment) + "\n")
+            default:
+                b.WriteString(pad + node.Key + " = " + stringifyBasic(v) + trailingCommentSuffix(node.TrailingComment) + "\n")
+            }
+        }
+    }
+    write(d, 0)
+    return b.String()
+}
+
+func trailingCommentSuffix(comment string) string {
+    if comment == "" {
+        return ""
+    }
+    return " # " + comment
+}
+
+// ToMap converts a Document to the plain map[string]interface{} shape used
+// by ParseFlow/StringifyFlow, discarding comments and key order.
+func (d *Document) ToMap() map[string]interface{} {
+    out := make(map[string]interface{}, len(d.nodes))
+    for _, node := range d.nodes {
+        if child, ok := node.Value.(*Document); ok {
+            out[node.Key] = child.ToMap()
+        } else {
+            out[node.Key] = node.Value
+        }
+    }
+    return out
+}
+
+// DocumentFromMap builds a Document from a plain map. Since Go map
+// iteration order is random, the resulting node order is unspecified; use
+// ParseFlowDocument when source order matters.
+func DocumentFromMap(m map[string]interface{}) *Document {
+    doc := NewDocument()
+    for key, value := range m {
+        if nested, ok := value.(map[string]interface{}); ok {
+            doc.Set(key, DocumentFromMap(nested))
+        } else {
+            doc.Set(key, value)
+        }
+    }
+    return doc
+}
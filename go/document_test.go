@@ -0,0 +1,90 @@
+package flowdoc
+
+import "testing"
+
+func TestParseFlowDocumentTopLevelNesting(t *testing.T) {
+    doc := ParseFlowDocument("base:\n  x = 1\n  y = 2\n")
+
+    base := doc.Get("base")
+    if base == nil {
+        t.Fatalf("expected top-level key %q, got none", "base")
+    }
+    child, ok := base.Value.(*Document)
+    if !ok {
+        t.Fatalf("expected %q to hold a nested Document, got %T", "base", base.Value)
+    }
+    if x := child.Get("x"); x == nil || x.Value != 1 {
+        t.Errorf("expected base.x = 1, got %v", x)
+    }
+    if y := child.Get("y"); y == nil || y.Value != 2 {
+        t.Errorf("expected base.y = 2, got %v", y)
+    }
+}
+
+func TestParseFlowDocumentDirectlyNestedObjects(t *testing.T) {
+    doc := ParseFlowDocument("a:\n  b:\n    c = 1\n")
+
+    a := doc.Get("a")
+    if a == nil {
+        t.Fatalf("expected top-level key %q, got none", "a")
+    }
+    aDoc, ok := a.Value.(*Document)
+    if !ok {
+        t.Fatalf("expected %q to hold a nested Document, got %T", "a", a.Value)
+    }
+
+    b := aDoc.Get("b")
+    if b == nil {
+        t.Fatalf("expected %q to be nested under %q, got a top-level key instead", "b", "a")
+    }
+    bDoc, ok := b.Value.(*Document)
+    if !ok {
+        t.Fatalf("expected %q to hold a nested Document, got %T", "b", b.Value)
+    }
+    if c := bDoc.Get("c"); c == nil || c.Value != 1 {
+        t.Errorf("expected a.b.c = 1, got %v", c)
+    }
+}
+
+func TestDocumentStringifyReemitsComments(t *testing.T) {
+    doc := NewDocument()
+    node := doc.Set("name", "Ada")
+    node.LeadingComments = []string{"the user's display name"}
+    node.TrailingComment = "required"
+
+    out := doc.Stringify()
+    want := "# the user's display name\nname = Ada # required\n"
+    if out != want {
+        t.Errorf("expected Stringify to re-emit leading and trailing comments, got %q, want %q", out, want)
+    }
+}
+
+func TestParseFlowDocumentStringifyRoundTripsComments(t *testing.T) {
+    text := "# a profile\nprofile:\n  # the user's display name\n  name = Ada # required\n"
+
+    doc := ParseFlowDocument(text)
+    out := doc.Stringify()
+    if out != text {
+        t.Errorf("expected comments to survive a Stringify round trip, got %q, want %q", out, text)
+    }
+
+    reparsed := ParseFlowDocument(out)
+    profile := reparsed.Get("profile")
+    if profile == nil {
+        t.Fatalf("expected top-level key %q, got none", "profile")
+    }
+    profileDoc, ok := profile.Value.(*Document)
+    if !ok {
+        t.Fatalf("expected %q to hold a nested Document, got %T", "profile", profile.Value)
+    }
+    name := profileDoc.Get("name")
+    if name == nil || name.Value != "Ada" {
+        t.Errorf("expected profile.name = Ada after round-tripping, got %v", name)
+    }
+    if len(name.LeadingComments) != 1 || name.LeadingComments[0] != "the user's display name" {
+        t.Errorf("expected the leading comment to survive the round trip, got %v", name.LeadingComments)
+    }
+    if name.TrailingComment != "required" {
+        t.Errorf("expected the trailing comment to survive the round trip, got %q", name.TrailingComment)
+    }
+}
@@ -5,13 +5,11 @@ import (
     "bytes"
     "encoding/json"
     "fmt"
-    "io/ioutil"
+    "io"
     "os"
     "regexp"
     "strconv"
     "strings"
-
-    msgpack "github.com/vmihailenco/msgpack/v5"
 )
 
 // ============================================
@@ -23,6 +21,19 @@ type FieldDefinition struct {
     Alias     string
     FieldType string
     FieldID   *int
+
+    // Validation constraints, borrowed from JSON Schema/OpenAPI. All are
+    // optional; a zero value means "not constrained", except Nullable,
+    // whose zero value (false) makes a nil value a violation.
+    Required  bool
+    Nullable  bool
+    Enum      []interface{}
+    Pattern   string // regex, strings only
+    Min       *float64
+    Max       *float64
+    MinLength *int
+    MaxLength *int
+    Default   interface{}
 }
 
 type ModelDefinition struct {
@@ -114,6 +125,23 @@ func parseTypedValue(raw string, fieldType string) (interface{}, error) {
     }
 }
 
+// toFloat64 coerces the numeric types that can come out of a parsed $models
+// block (int, float64, etc.) into a float64 for range comparisons.
+func toFloat64(v interface{}) (float64, bool) {
+    switch n := v.(type) {
+    case int:
+        return float64(n), true
+    case int64:
+        return float64(n), true
+    case float64:
+        return n, true
+    case float32:
+        return float64(n), true
+    default:
+        return 0, false
+    }
+}
+
 func extractModelsFromMap(data map[string]interface{}) *ModelRegistry {
     modelsVal, ok := data["$models"]
     if !ok {
@@ -179,6 +207,44 @@ func extractModelsFromMap(data map[string]interface{}) *ModelRegistry {
                 FieldID:   fieldID,
             }
 
+            if req, ok := fieldSpec["required"].(bool); ok {
+                fieldDef.Required = req
+            }
+            if nullable, ok := fieldSpec["nullable"].(bool); ok {
+                fieldDef.Nullable = nullable
+            }
+            if enum, ok := fieldSpec["enum"].([]interface{}); ok {
+                fieldDef.Enum = enum
+            }
+            if pattern, ok := fieldSpec["pattern"].(string); ok {
+                fieldDef.Pattern = pattern
+            }
+            if min, ok := fieldSpec["min"]; ok {
+                if f, ok := toFloat64(min); ok {
+                    fieldDef.Min = &f
+                }
+            }
+            if max, ok := fieldSpec["max"]; ok {
+                if f, ok := toFloat64(max); ok {
+                    fieldDef.Max = &f
+                }
+            }
+            if minLen, ok := fieldSpec["minLength"]; ok {
+                if f, ok := toFloat64(minLen); ok {
+                    n := int(f)
+                    fieldDef.MinLength = &n
+                }
+            }
+            if maxLen, ok := fieldSpec["maxLength"]; ok {
+                if f, ok := toFloat64(maxLen); ok {
+                    n := int(f)
+                    fieldDef.MaxLength = &n
+                }
+            }
+            if def, ok := fieldSpec["default"]; ok {
+                fieldDef.Default = def
+            }
+
             modelDef.AddField(fieldDef)
         }
 
@@ -188,6 +254,10 @@ func extractModelsFromMap(data map[string]interface{}) *ModelRegistry {
     return registry
 }
 
+// applyModelToMap resolves aliases to full names and converts each value to
+// the type its FieldDefinition declares (see parseTypedValue), then fills
+// in FieldDefinition.Default for any field the model declares that data
+// left out entirely.
 func applyModelToMap(data map[string]interface{}, model *ModelDefinition) map[string]interface{} {
     result := make(map[string]interface{})
 
@@ -227,6 +297,15 @@ func applyModelToMap(data map[string]interface{}, model *ModelDefinition) map[st
         }
     }
 
+    for fullName, fieldDef := range model.Fields {
+        if fieldDef.Default == nil {
+            continue
+        }
+        if _, ok := result[fullName]; !ok {
+            result[fullName] = fieldDef.Default
+        }
+    }
+
     return result
 }
 
@@ -234,21 +313,65 @@ func applyModelToMap(data map[string]interface{}, model *ModelDefinition) map[st
 // Core Parsing Functions
 // ============================================
 
-func tokenizeLines(text string) []string {
-    text = strings.ReplaceAll(text, "\t", "  ")
-    var out []string
+// tokenLine is one non-blank source line with its comment split out: Text
+// is the code portion (leading indentation intact, trailing whitespace
+// trimmed), Comment is whatever followed an inline "#" on that same line,
+// and Leading holds any whole-line "# ..." comments that appeared directly
+// above it. ParseFlowDocument uses all three to keep comments alive in the
+// tree (see document.go); ParseFlow does not go through tokenizeLines, it
+// reads lines via Decoder.fill and splitComment.
+type tokenLine struct {
+    Text    string
+    Comment string
+    Leading []string
+}
+
+func tokenizeLines(text string) []tokenLine {
+    var out []tokenLine
+    var pendingLeading []string
     scanner := bufio.NewScanner(strings.NewReader(text))
     for scanner.Scan() {
         line := scanner.Text()
-        parts := strings.SplitN(line, "#", 2)
-        no := strings.TrimRight(parts[0], " \t")
-        if strings.TrimSpace(no) != "" {
-            out = append(out, no)
+        if strings.HasPrefix(strings.TrimSpace(line), "#") {
+            pendingLeading = append(pendingLeading, strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), "#")))
+            continue
+        }
+        code, comment, blank := splitComment(line)
+        if blank {
+            continue
         }
+        out = append(out, tokenLine{Text: code, Comment: comment, Leading: pendingLeading})
+        pendingLeading = nil
     }
     return out
 }
 
+var leadingWhitespaceRe = regexp.MustCompile(`^\s*`)
+
+// indentOf returns a line's FlowDoc nesting depth, one level per two
+// leading spaces. Shared by every indent-stack parser in this package
+// (tokenizeLines/ParseFlowDocument/Decoder.fill) so they can't
+// independently drift on what "more indented" means.
+func indentOf(line string) int {
+    return len(leadingWhitespaceRe.FindString(line)) / 2
+}
+
+// splitComment splits a single physical line into its code portion and
+// an inline "# ..." comment, if any, expanding tabs first. blank reports
+// whether the code portion, once the comment is stripped, is empty.
+// Shared by tokenizeLines (batch parsing) and Decoder.fill (streaming
+// parsing) so the two can't drift on what counts as a comment or a blank
+// line.
+func splitComment(line string) (code string, comment string, blank bool) {
+    line = strings.ReplaceAll(line, "\t", "  ")
+    parts := strings.SplitN(line, "#", 2)
+    code = strings.TrimRight(parts[0], " \t")
+    if len(parts) == 2 {
+        comment = strings.TrimSpace(parts[1])
+    }
+    return code, comment, strings.TrimSpace(code) == ""
+}
+
 func parseValue(raw string) interface{} {
     v := strings.TrimSpace(raw)
     if v == "true" {
@@ -284,66 +407,69 @@ func parseValue(raw string) interface{} {
     return v
 }
 
+// ParseFlow parses FlowDoc text into a plain map. Anchor/alias errors
+// (undefined references, cycles) are swallowed and left as a nil value;
+// use ParseFlowStrict to surface them.
 func ParseFlow(text string) map[string]interface{} {
-    lines := tokenizeLines(text)
-    root := make(map[string]interface{})
-    stack := []struct{
-        indent int
-        node map[string]interface{}
-    }{{0, root}}
-
-    for _, line := range lines {
-        leading := len(regexp.MustCompile(`^\s*`).FindString(line))
-        indent := leading / 2
-        trimmed := strings.TrimSpace(line)
-        if strings.HasSuffix(trimmed, ":") {
-            key := strings.TrimSpace(trimmed[:len(trimmed)-1])
-            obj := make(map[string]interface{})
-            for len(stack) > 0 && stack[len(stack)-1].indent >= indent {
-                stack = stack[:len(stack)-1]
-            }
-            stack[len(stack)-1].node[key] = obj
-            stack = append(stack, struct{
-                indent int
-                node map[string]interface{}
-            }{indent+1, obj})
-        } else {
-            if !strings.Contains(trimmed, "=") { continue }
-            parts := strings.SplitN(trimmed, "=", 2)
-            key := strings.TrimSpace(parts[0])
-            raw := strings.TrimSpace(parts[1])
-            for len(stack) > 0 && stack[len(stack)-1].indent > indent {
-                stack = stack[:len(stack)-1]
-            }
-            stack[len(stack)-1].node[key] = parseValue(raw)
+    root, _ := parseFlowTree(text, false)
+    return root
+}
+
+// ParseFlowStrict behaves like ParseFlow but returns an error if an
+// `*anchor_name` reference is undefined or anchors form a cycle.
+func ParseFlowStrict(text string) (map[string]interface{}, error) {
+    return parseFlowTree(text, true)
+}
+
+// parseFlowTree builds the tree and resolves anchor/alias references
+// (see anchors.go), by driving a Decoder over text and assembling its
+// event stream into a map -- the same primitive ParseFlow's streaming
+// sibling (NewDecoder/Next) is built on, so the two can't drift on the
+// grammar the way separately-maintained implementations would. When
+// strict is false, resolution errors are discarded and best-effort
+// values are kept.
+func parseFlowTree(text string, strict bool) (map[string]interface{}, error) {
+    root, anchors, err := NewDecoder(strings.NewReader(text)).decodeTreeWithAnchors()
+    if err != nil {
+        if strict {
+            return nil, err
         }
+        return root, nil
     }
-    return root
+
+    resolved, err := resolveAnchors(root, anchors)
+    if err != nil && strict {
+        return nil, err
+    }
+    return resolved, nil
 }
 
+// StringifyFlow renders obj as FlowDoc text; it and Encoder.Encode both
+// build on writeFlowObj so there is a single implementation of the
+// format's output side.
 func StringifyFlow(obj map[string]interface{}) string {
     var b bytes.Buffer
-    var writeObj func(map[string]interface{}, int)
-    writeObj = func(o map[string]interface{}, indent int) {
-        pad := strings.Repeat(" ", indent)
-        for k, v := range o {
-            switch vv := v.(type) {
-            case map[string]interface{}:
-                b.WriteString(pad + k + ":\n")
-                writeObj(vv, indent+2)
-            case []interface{}:
-                arr := make([]string, 0, len(vv))
-                for _, e := range vv {
-                    arr = append(arr, stringifyBasic(e))
-                }
-                b.WriteString(pad + k + " = [" + strings.Join(arr, ", ") + "]\n")
-            default:
-                b.WriteString(pad + k + " = " + stringifyBasic(v) + "\n")
+    writeFlowObj(&b, obj, 0)
+    return b.String()
+}
+
+func writeFlowObj(w io.Writer, o map[string]interface{}, indent int) {
+    pad := strings.Repeat(" ", indent)
+    for k, v := range o {
+        switch vv := v.(type) {
+        case map[string]interface{}:
+            io.WriteString(w, pad+k+":\n")
+            writeFlowObj(w, vv, indent+2)
+        case []interface{}:
+            arr := make([]string, 0, len(vv))
+            for _, e := range vv {
+                arr = append(arr, stringifyBasic(e))
             }
+            io.WriteString(w, pad+k+" = ["+strings.Join(arr, ", ")+"]\n")
+        default:
+            io.WriteString(w, pad+k+" = "+stringifyBasic(v)+"\n")
         }
     }
-    writeObj(obj, 0)
-    return b.String()
 }
 
 func stringifyBasic(v interface{}) string {
@@ -364,27 +490,39 @@ func stringifyBasic(v interface{}) string {
 }
 
 func LoadFlow(path string) (map[string]interface{}, error) {
-    data, err := ioutil.ReadFile(path)
+    data, err := os.ReadFile(path)
     if err != nil { return nil, err }
     return ParseFlow(string(data)), nil
 }
 
 func SaveFlow(path string, obj map[string]interface{}) error {
-    return ioutil.WriteFile(path, []byte(StringifyFlow(obj)), 0644)
+    return os.WriteFile(path, []byte(StringifyFlow(obj)), 0644)
 }
 
+// LoadFlowb reads a .flowb (msgpack) file. It is a thin wrapper around
+// FlowbStreamDecoder, the same primitive callers scanning a .flowb file
+// record-by-record use; see LoadBinary for other formats.
 func LoadFlowb(path string) (map[string]interface{}, error) {
-    data, err := ioutil.ReadFile(path)
-    if err != nil { return nil, err }
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
     var out map[string]interface{}
-    err = msgpack.Unmarshal(data, &out)
+    err = NewFlowbStreamDecoder(f).Decode(&out)
     return out, err
 }
 
+// SaveFlowb writes obj as a .flowb (msgpack) file. It is a thin wrapper
+// around FlowbStreamEncoder, the write-side counterpart of
+// FlowbStreamDecoder; see SaveBinary for other formats.
 func SaveFlowb(path string, obj map[string]interface{}) error {
-    data, err := msgpack.Marshal(obj)
-    if err != nil { return err }
-    return ioutil.WriteFile(path, data, 0644)
+    f, err := os.Create(path)
+    if err != nil {
+        return err
+    }
+    defer f.Close()
+    return NewFlowbStreamEncoder(f).Encode(obj)
 }
 
 func ConvertFlowToJSON(flowText string) (string, error) {
@@ -399,7 +537,18 @@ func ConvertJSONToFlow(jsonText string) (string, error) {
     return StringifyFlow(obj), nil
 }
 
+// ParseFlowOptions controls optional behavior of ParseFlowWithModelOptions.
+type ParseFlowOptions struct {
+    // StrictValidate runs ValidateAgainstModel on the result after the model
+    // is applied and turns any ValidationError into the returned error.
+    StrictValidate bool
+}
+
 func ParseFlowWithModel(text string, registry *ModelRegistry) (map[string]interface{}, error) {
+    return ParseFlowWithModelOptions(text, registry, ParseFlowOptions{})
+}
+
+func ParseFlowWithModelOptions(text string, registry *ModelRegistry, opts ParseFlowOptions) (map[string]interface{}, error) {
     // First, parse normally
     data := ParseFlow(text)
 
@@ -450,6 +599,12 @@ func ParseFlowWithModel(text string, registry *ModelRegistry) (map[string]interf
                 }
             }
 
+            if opts.StrictValidate {
+                if errs := ValidateAgainstModel(finalResult, model); len(errs) > 0 {
+                    return nil, fmt.Errorf("flowdoc: validation failed: %v", errs)
+                }
+            }
+
             return finalResult, nil
         }
     }
@@ -470,7 +625,7 @@ func ParseFlowWithModel(text string, registry *ModelRegistry) (map[string]interf
 }
 
 func LoadFlowWithModel(path string, registry *ModelRegistry) (map[string]interface{}, error) {
-    data, err := ioutil.ReadFile(path)
+    data, err := os.ReadFile(path)
     if err != nil {
         return nil, err
     }
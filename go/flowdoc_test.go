@@ -0,0 +1,39 @@
+package flowdoc
+
+import "testing"
+
+func TestParseFlowWithModelAppliesFieldDefault(t *testing.T) {
+    text := "$models:\n" +
+        "  User:\n" +
+        "    fields:\n" +
+        "      name:\n" +
+        "        type = \"string\"\n" +
+        "      role:\n" +
+        "        type = \"string\"\n" +
+        "        default = \"member\"\n" +
+        "use_model = \"User\"\n" +
+        "profile:\n" +
+        "  name = \"Ada\"\n"
+
+    result, err := ParseFlowWithModel(text, nil)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+    profile, ok := result["profile"].(map[string]interface{})
+    if !ok {
+        t.Fatalf("expected %q to hold a map, got %v", "profile", result["profile"])
+    }
+    if profile["role"] != "member" {
+        t.Errorf("expected the missing role field to fall back to its default, got %v", profile["role"])
+    }
+}
+
+func TestApplyModelToMapDefaultDoesNotOverrideExplicitValue(t *testing.T) {
+    model := NewModelDefinition("User")
+    model.AddField(&FieldDefinition{FullName: "role", Alias: "role", FieldType: "string", Default: "member"})
+
+    result := applyModelToMap(map[string]interface{}{"role": "admin"}, model)
+    if result["role"] != "admin" {
+        t.Errorf("expected an explicit value to win over the default, got %v", result["role"])
+    }
+}
@@ -0,0 +1,249 @@
+package flowdoc
+
+import (
+    "encoding/json"
+    "fmt"
+    "sort"
+)
+
+// ============================================
+// JSON Schema / OpenAPI Interop
+// ============================================
+
+// jsonSchemaProperty mirrors the subset of JSON Schema / OpenAPI 3.1 keywords
+// FlowDoc needs to describe a single FieldDefinition. Required is not here:
+// JSON Schema declares it on the enclosing object schema as a "required"
+// array of property names, not per-property. Nullable uses the OpenAPI 3.0
+// "nullable" keyword rather than a draft-2020-12 "type" array, to keep Type
+// a plain string for every other field on this struct.
+type jsonSchemaProperty struct {
+    Type      string        `json:"type,omitempty"`
+    Format    string        `json:"format,omitempty"`
+    Nullable  bool          `json:"nullable,omitempty"`
+    Enum      []interface{} `json:"enum,omitempty"`
+    Pattern   string        `json:"pattern,omitempty"`
+    Minimum   *float64      `json:"minimum,omitempty"`
+    Maximum   *float64      `json:"maximum,omitempty"`
+    MinLength *int          `json:"minLength,omitempty"`
+    MaxLength *int          `json:"maxLength,omitempty"`
+    Default   interface{}   `json:"default,omitempty"`
+    FullName  string        `json:"x-fullname,omitempty"`
+    FieldID   *int          `json:"x-field-id,omitempty"`
+}
+
+// fieldTypeToJSONSchema maps a FlowDoc FieldDefinition.FieldType to the
+// corresponding JSON Schema "type"/"format" pair.
+func fieldTypeToJSONSchema(fieldType string) (schemaType string, format string) {
+    switch fieldType {
+    case "int":
+        return "integer", ""
+    case "float":
+        return "number", ""
+    case "bool":
+        return "boolean", ""
+    case "date":
+        return "string", "date"
+    case "datetime":
+        return "string", "date-time"
+    default: // "string" or unknown
+        return "string", ""
+    }
+}
+
+// jsonSchemaFromModel builds the {"type":"object","properties":{...}} document
+// for a single ModelDefinition, shared by ToJSONSchema and ToOpenAPISchema.
+// Every constraint ValidateAgainstModel enforces is carried over, so the
+// exported schema is round-trippable through LoadModelsFromJSONSchema
+// instead of silently dropping them for downstream validators/generators.
+func jsonSchemaFromModel(model *ModelDefinition) map[string]interface{} {
+    properties := make(map[string]interface{}, len(model.Fields))
+    var required []string
+    for _, field := range model.Fields {
+        schemaType, format := fieldTypeToJSONSchema(field.FieldType)
+        prop := jsonSchemaProperty{
+            Type:      schemaType,
+            Format:    format,
+            Nullable:  field.Nullable,
+            Enum:      field.Enum,
+            Pattern:   field.Pattern,
+            Minimum:   field.Min,
+            Maximum:   field.Max,
+            MinLength: field.MinLength,
+            MaxLength: field.MaxLength,
+            Default:   field.Default,
+            FullName:  field.FullName,
+            FieldID:   field.FieldID,
+        }
+        properties[field.Alias] = prop
+        if field.Required {
+            required = append(required, field.Alias)
+        }
+    }
+    sort.Strings(required)
+
+    doc := map[string]interface{}{
+        "type":       "object",
+        "properties": properties,
+    }
+    if len(required) > 0 {
+        doc["required"] = required
+    }
+    return doc
+}
+
+// ToJSONSchema renders the registry as a JSON Schema draft-2020-12 document,
+// one entry per model under "$defs".
+func (r *ModelRegistry) ToJSONSchema() ([]byte, error) {
+    defs := make(map[string]interface{}, len(r.models))
+    for name, model := range r.models {
+        defs[name] = jsonSchemaFromModel(model)
+    }
+    doc := map[string]interface{}{
+        "$schema": "https://json-schema.org/draft/2020-12/schema",
+        "$defs":   defs,
+    }
+    return json.MarshalIndent(doc, "", "  ")
+}
+
+// ToOpenAPISchema renders the registry as an OpenAPI 3.1 document fragment,
+// one entry per model under "components.schemas".
+func (r *ModelRegistry) ToOpenAPISchema() ([]byte, error) {
+    schemas := make(map[string]interface{}, len(r.models))
+    for name, model := range r.models {
+        schemas[name] = jsonSchemaFromModel(model)
+    }
+    doc := map[string]interface{}{
+        "openapi": "3.1.0",
+        "components": map[string]interface{}{
+            "schemas": schemas,
+        },
+    }
+    return json.MarshalIndent(doc, "", "  ")
+}
+
+// LoadModelsFromJSONSchema is the inverse of ToJSONSchema/ToOpenAPISchema: it
+// accepts either document shape and reconstructs a ModelRegistry from it.
+func LoadModelsFromJSONSchema(data []byte) (*ModelRegistry, error) {
+    var doc map[string]interface{}
+    if err := json.Unmarshal(data, &doc); err != nil {
+        return nil, fmt.Errorf("flowdoc: invalid JSON schema document: %w", err)
+    }
+
+    defs, ok := doc["$defs"].(map[string]interface{})
+    if !ok {
+        if components, ok := doc["components"].(map[string]interface{}); ok {
+            defs, _ = components["schemas"].(map[string]interface{})
+        }
+    }
+    if defs == nil {
+        return nil, fmt.Errorf("flowdoc: no $defs or components.schemas found")
+    }
+
+    registry := NewModelRegistry()
+    for modelName, modelSchemaVal := range defs {
+        modelSchema, ok := modelSchemaVal.(map[string]interface{})
+        if !ok {
+            continue
+        }
+        properties, ok := modelSchema["properties"].(map[string]interface{})
+        if !ok {
+            continue
+        }
+
+        required := make(map[string]bool)
+        if reqVal, ok := modelSchema["required"].([]interface{}); ok {
+            for _, r := range reqVal {
+                if alias, ok := r.(string); ok {
+                    required[alias] = true
+                }
+            }
+        }
+
+        modelDef := NewModelDefinition(modelName)
+        for alias, propVal := range properties {
+            prop, ok := propVal.(map[string]interface{})
+            if !ok {
+                continue
+            }
+
+            fullName := alias
+            if fn, ok := prop["x-fullname"].(string); ok && fn != "" {
+                fullName = fn
+            }
+
+            fieldType := jsonSchemaToFieldType(prop)
+
+            var fieldID *int
+            if idVal, ok := prop["x-field-id"]; ok {
+                if idFloat, ok := idVal.(float64); ok {
+                    id := int(idFloat)
+                    fieldID = &id
+                }
+            }
+
+            nullable, _ := prop["nullable"].(bool)
+            fieldDef := &FieldDefinition{
+                FullName:  fullName,
+                Alias:     alias,
+                FieldType: fieldType,
+                FieldID:   fieldID,
+                Required:  required[alias],
+                Nullable:  nullable,
+            }
+            if enum, ok := prop["enum"].([]interface{}); ok {
+                fieldDef.Enum = enum
+            }
+            if pattern, ok := prop["pattern"].(string); ok {
+                fieldDef.Pattern = pattern
+            }
+            if min, ok := prop["minimum"].(float64); ok {
+                fieldDef.Min = &min
+            }
+            if max, ok := prop["maximum"].(float64); ok {
+                fieldDef.Max = &max
+            }
+            if minLen, ok := prop["minLength"].(float64); ok {
+                n := int(minLen)
+                fieldDef.MinLength = &n
+            }
+            if maxLen, ok := prop["maxLength"].(float64); ok {
+                n := int(maxLen)
+                fieldDef.MaxLength = &n
+            }
+            if def, ok := prop["default"]; ok {
+                fieldDef.Default = def
+            }
+
+            modelDef.AddField(fieldDef)
+        }
+        registry.RegisterModel(modelDef)
+    }
+
+    return registry, nil
+}
+
+// jsonSchemaToFieldType is the inverse of fieldTypeToJSONSchema.
+func jsonSchemaToFieldType(prop map[string]interface{}) string {
+    schemaType, _ := prop["type"].(string)
+    format, _ := prop["format"].(string)
+
+    switch schemaType {
+    case "integer":
+        return "int"
+    case "number":
+        return "float"
+    case "boolean":
+        return "bool"
+    case "string":
+        switch format {
+        case "date":
+            return "date"
+        case "date-time":
+            return "datetime"
+        default:
+            return "string"
+        }
+    default:
+        return "string"
+    }
+}
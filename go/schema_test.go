@@ -0,0 +1,103 @@
+package flowdoc
+
+import "testing"
+
+func TestJSONSchemaRoundTripsValidationConstraints(t *testing.T) {
+    min, max := 0.0, 120.0
+    minLen, maxLen := 1, 40
+
+    registry := NewModelRegistry()
+    model := NewModelDefinition("User")
+    model.AddField(&FieldDefinition{
+        FullName:  "name",
+        Alias:     "name",
+        FieldType: "string",
+        Required:  true,
+        MinLength: &minLen,
+        MaxLength: &maxLen,
+    })
+    model.AddField(&FieldDefinition{
+        FullName:  "age",
+        Alias:     "age",
+        FieldType: "int",
+        Min:       &min,
+        Max:       &max,
+    })
+    model.AddField(&FieldDefinition{
+        FullName:  "middleName",
+        Alias:     "middleName",
+        FieldType: "string",
+        Nullable:  true,
+    })
+    model.AddField(&FieldDefinition{
+        FullName:  "role",
+        Alias:     "role",
+        FieldType: "string",
+        Enum:      []interface{}{"admin", "member"},
+        Pattern:   "^[a-z]+$",
+        Default:   "member",
+    })
+    registry.RegisterModel(model)
+
+    data, err := registry.ToJSONSchema()
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    loaded, err := LoadModelsFromJSONSchema(data)
+    if err != nil {
+        t.Fatalf("unexpected error: %v", err)
+    }
+
+    got := loaded.GetModel("User")
+    if got == nil {
+        t.Fatalf("expected model %q to round-trip, got none", "User")
+    }
+
+    name := got.Fields["name"]
+    if name == nil {
+        t.Fatalf("expected field %q to round-trip", "name")
+    }
+    if !name.Required {
+        t.Error("expected name.Required = true to round-trip")
+    }
+    if name.MinLength == nil || *name.MinLength != 1 {
+        t.Errorf("expected name.MinLength = 1, got %v", name.MinLength)
+    }
+    if name.MaxLength == nil || *name.MaxLength != 40 {
+        t.Errorf("expected name.MaxLength = 40, got %v", name.MaxLength)
+    }
+
+    age := got.Fields["age"]
+    if age == nil {
+        t.Fatalf("expected field %q to round-trip", "age")
+    }
+    if age.Min == nil || *age.Min != 0 {
+        t.Errorf("expected age.Min = 0, got %v", age.Min)
+    }
+    if age.Max == nil || *age.Max != 120 {
+        t.Errorf("expected age.Max = 120, got %v", age.Max)
+    }
+
+    middleName := got.Fields["middleName"]
+    if middleName == nil {
+        t.Fatalf("expected field %q to round-trip", "middleName")
+    }
+    if !middleName.Nullable {
+        t.Error("expected middleName.Nullable = true to round-trip")
+    }
+
+    role := got.Fields["role"]
+    if role == nil {
+        t.Fatalf("expected field %q to round-trip", "role")
+    }
+    if len(role.Enum) != 2 || role.Enum[0] != "admin" || role.Enum[1] != "member" {
+        t.Errorf("expected role.Enum = [admin, member], got %v", role.Enum)
+    }
+    if role.Pattern != "^[a-z]+$" {
+        t.Errorf("expected role.Pattern = %q, got %q", "^[a-z]+$", role.Pattern)
+    }
+    if role.Default != "member" {
+        t.Errorf("expected role.Default = %q, got %v", "member", role.Default)
+    }
+}
@@ -0,0 +1,114 @@
+package flowdoc
+
+import (
+    "fmt"
+    "regexp"
+)
+
+// ============================================
+// Model Validation
+// ============================================
+
+// ValidationError describes a single constraint violation found while
+// checking a parsed document against a ModelDefinition. Path is a
+// JSON-pointer-style path (e.g. "/user/age") identifying where the
+// violation occurred.
+type ValidationError struct {
+    Path    string
+    Message string
+}
+
+func (e *ValidationError) Error() string {
+    return fmt.Sprintf("%s: %s", e.Path, e.Message)
+}
+
+// ValidateAgainstModel checks data against the constraints declared on
+// model's FieldDefinitions and returns every violation found. At each level
+// it looks up every field the model declares by full name; where such a
+// field holds a map, or an array of maps, those are validated against the
+// same model. Nested maps under keys the model does not declare are not
+// visited, unlike applyModelToMap, which descends into every nested map.
+func ValidateAgainstModel(data map[string]interface{}, model *ModelDefinition) []ValidationError {
+    var errs []ValidationError
+    validateMap(data, model, "", &errs)
+    return errs
+}
+
+func validateMap(data map[string]interface{}, model *ModelDefinition, path string, errs *[]ValidationError) {
+    for _, field := range model.Fields {
+        value, present := data[field.FullName]
+        fieldPath := path + "/" + field.FullName
+
+        if !present {
+            if field.Required {
+                *errs = append(*errs, ValidationError{Path: fieldPath, Message: "required field is missing"})
+            }
+            continue
+        }
+
+        if value == nil {
+            if !field.Nullable {
+                *errs = append(*errs, ValidationError{Path: fieldPath, Message: "field is not nullable"})
+            }
+            continue
+        }
+
+        switch v := value.(type) {
+        case map[string]interface{}:
+            validateMap(v, model, fieldPath, errs)
+        case []interface{}:
+            for i, item := range v {
+                if itemMap, ok := item.(map[string]interface{}); ok {
+                    validateMap(itemMap, model, fmt.Sprintf("%s/%d", fieldPath, i), errs)
+                }
+            }
+        default:
+            validateScalar(value, field, fieldPath, errs)
+        }
+    }
+}
+
+func validateScalar(value interface{}, field *FieldDefinition, path string, errs *[]ValidationError) {
+    if len(field.Enum) > 0 && !valueInEnum(value, field.Enum) {
+        *errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("value %v is not one of %v", value, field.Enum)})
+    }
+
+    if field.Pattern != "" {
+        if s, ok := value.(string); ok {
+            if matched, err := regexp.MatchString(field.Pattern, s); err != nil || !matched {
+                *errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("value %q does not match pattern %q", s, field.Pattern)})
+            }
+        }
+    }
+
+    if field.Min != nil || field.Max != nil {
+        if f, ok := toFloat64(value); ok {
+            if field.Min != nil && f < *field.Min {
+                *errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("value %v is below minimum %v", f, *field.Min)})
+            }
+            if field.Max != nil && f > *field.Max {
+                *errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("value %v is above maximum %v", f, *field.Max)})
+            }
+        }
+    }
+
+    if field.MinLength != nil || field.MaxLength != nil {
+        if s, ok := value.(string); ok {
+            if field.MinLength != nil && len(s) < *field.MinLength {
+                *errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("length %d is below minLength %d", len(s), *field.MinLength)})
+            }
+            if field.MaxLength != nil && len(s) > *field.MaxLength {
+                *errs = append(*errs, ValidationError{Path: path, Message: fmt.Sprintf("length %d is above maxLength %d", len(s), *field.MaxLength)})
+            }
+        }
+    }
+}
+
+func valueInEnum(value interface{}, enum []interface{}) bool {
+    for _, candidate := range enum {
+        if fmt.Sprintf("%v", candidate) == fmt.Sprintf("%v", value) {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,151 @@
+package flowdoc
+
+import "testing"
+
+func newValidationTestModel() *ModelDefinition {
+    min, max := 0.0, 120.0
+    minLen, maxLen := 2, 10
+
+    model := NewModelDefinition("User")
+    model.AddField(&FieldDefinition{FullName: "name", Alias: "name", FieldType: "string", Required: true, MinLength: &minLen, MaxLength: &maxLen})
+    model.AddField(&FieldDefinition{FullName: "age", Alias: "age", FieldType: "int", Min: &min, Max: &max})
+    model.AddField(&FieldDefinition{FullName: "role", Alias: "role", FieldType: "string", Enum: []interface{}{"admin", "member"}})
+    model.AddField(&FieldDefinition{FullName: "email", Alias: "email", FieldType: "string", Pattern: `^[^@]+@[^@]+$`})
+    model.AddField(&FieldDefinition{FullName: "nickname", Alias: "nickname", FieldType: "string", Nullable: false})
+    return model
+}
+
+func hasErrorForPath(errs []ValidationError, path string) bool {
+    for _, e := range errs {
+        if e.Path == path {
+            return true
+        }
+    }
+    return false
+}
+
+func TestValidateAgainstModelRequiredMissing(t *testing.T) {
+    model := newValidationTestModel()
+    errs := ValidateAgainstModel(map[string]interface{}{}, model)
+    if !hasErrorForPath(errs, "/name") {
+        t.Errorf("expected a violation for missing required field %q, got %v", "name", errs)
+    }
+}
+
+func TestValidateAgainstModelEnumViolation(t *testing.T) {
+    model := newValidationTestModel()
+    errs := ValidateAgainstModel(map[string]interface{}{"name": "Ada", "role": "superuser"}, model)
+    if !hasErrorForPath(errs, "/role") {
+        t.Errorf("expected a violation for %q not in the enum, got %v", "role", errs)
+    }
+}
+
+func TestValidateAgainstModelEnumAccepted(t *testing.T) {
+    model := newValidationTestModel()
+    errs := ValidateAgainstModel(map[string]interface{}{"name": "Ada", "role": "admin"}, model)
+    if hasErrorForPath(errs, "/role") {
+        t.Errorf("did not expect a violation for an allowed enum value, got %v", errs)
+    }
+}
+
+func TestValidateAgainstModelPatternViolation(t *testing.T) {
+    model := newValidationTestModel()
+    errs := ValidateAgainstModel(map[string]interface{}{"name": "Ada", "email": "not-an-email"}, model)
+    if !hasErrorForPath(errs, "/email") {
+        t.Errorf("expected a violation for an email not matching the pattern, got %v", errs)
+    }
+}
+
+func TestValidateAgainstModelMinMaxViolation(t *testing.T) {
+    model := newValidationTestModel()
+
+    tooYoung := ValidateAgainstModel(map[string]interface{}{"name": "Ada", "age": -1}, model)
+    if !hasErrorForPath(tooYoung, "/age") {
+        t.Errorf("expected a violation for age below minimum, got %v", tooYoung)
+    }
+
+    tooOld := ValidateAgainstModel(map[string]interface{}{"name": "Ada", "age": 200}, model)
+    if !hasErrorForPath(tooOld, "/age") {
+        t.Errorf("expected a violation for age above maximum, got %v", tooOld)
+    }
+
+    inRange := ValidateAgainstModel(map[string]interface{}{"name": "Ada", "age": 30}, model)
+    if hasErrorForPath(inRange, "/age") {
+        t.Errorf("did not expect a violation for an in-range age, got %v", inRange)
+    }
+}
+
+func TestValidateAgainstModelMinMaxLengthViolation(t *testing.T) {
+    model := newValidationTestModel()
+
+    tooShort := ValidateAgainstModel(map[string]interface{}{"name": "A"}, model)
+    if !hasErrorForPath(tooShort, "/name") {
+        t.Errorf("expected a violation for a name shorter than minLength, got %v", tooShort)
+    }
+
+    tooLong := ValidateAgainstModel(map[string]interface{}{"name": "WayTooLongAName"}, model)
+    if !hasErrorForPath(tooLong, "/name") {
+        t.Errorf("expected a violation for a name longer than maxLength, got %v", tooLong)
+    }
+}
+
+func TestValidateAgainstModelNullable(t *testing.T) {
+    model := newValidationTestModel()
+
+    notNullable := ValidateAgainstModel(map[string]interface{}{"name": "Ada", "nickname": nil}, model)
+    if !hasErrorForPath(notNullable, "/nickname") {
+        t.Errorf("expected a violation for a nil value on a non-nullable field, got %v", notNullable)
+    }
+
+    model.Fields["nickname"].Nullable = true
+    nullable := ValidateAgainstModel(map[string]interface{}{"name": "Ada", "nickname": nil}, model)
+    if hasErrorForPath(nullable, "/nickname") {
+        t.Errorf("did not expect a violation for a nil value on a nullable field, got %v", nullable)
+    }
+}
+
+func TestValidateAgainstModelValidDataHasNoErrors(t *testing.T) {
+    model := newValidationTestModel()
+    errs := ValidateAgainstModel(map[string]interface{}{
+        "name":  "Ada",
+        "age":   30,
+        "role":  "admin",
+        "email": "ada@example.com",
+    }, model)
+    if len(errs) != 0 {
+        t.Errorf("expected no violations for fully valid data, got %v", errs)
+    }
+}
+
+func TestParseFlowWithModelOptionsStrictValidateRejectsInvalidData(t *testing.T) {
+    // ValidateAgainstModel (see validateMap) checks required fields against
+    // the top level of the model-applied result, so the field has to sit at
+    // the document's top level, not nested under some other key, for this
+    // to exercise the required check rather than just never looking at it.
+    text := "$models:\n" +
+        "  User:\n" +
+        "    fields:\n" +
+        "      name:\n" +
+        "        type = \"string\"\n" +
+        "        required = true\n" +
+        "use_model = \"User\"\n"
+
+    if _, err := ParseFlowWithModelOptions(text, nil, ParseFlowOptions{StrictValidate: true}); err == nil {
+        t.Fatal("expected an error when a required field is missing under StrictValidate")
+    }
+}
+
+func TestParseFlowWithModelOptionsStrictValidateAcceptsValidData(t *testing.T) {
+    text := "$models:\n" +
+        "  User:\n" +
+        "    fields:\n" +
+        "      name:\n" +
+        "        type = \"string\"\n" +
+        "        required = true\n" +
+        "use_model = \"User\"\n" +
+        "name = \"Ada\"\n"
+
+    if _, err := ParseFlowWithModelOptions(text, nil, ParseFlowOptions{StrictValidate: true}); err != nil {
+        t.Fatalf("unexpected error for valid data: %v", err)
+    }
+}